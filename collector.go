@@ -0,0 +1,305 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultStaleAfter is how long a gauge-style value is still reported once
+// its source block stops arriving, absent an explicit
+// StationConfig.StaleAfterSeconds. This is what lets a disconnected
+// station's last-known values drop out of scrapes instead of reporting
+// stale numbers forever.
+const defaultStaleAfter = 30 * time.Second
+
+// gaugeValue is a single scrape-time buffered gauge reading.
+type gaugeValue struct {
+	value float64
+	seen  time.Time
+	set   bool
+}
+
+func (g *gaugeValue) update(v float64) {
+	g.value = v
+	g.seen = time.Now()
+	g.set = true
+}
+
+func (g gaugeValue) valid(ttl time.Duration) bool {
+	return g.set && time.Since(g.seen) < ttl
+}
+
+// StationState buffers the most recently decoded SBF values for one
+// station. Decoders write into it as blocks arrive; StationCollector reads
+// it at scrape time. This separates push-style stream ingestion from
+// pull-style scraping, so a scrape always sees a consistent snapshot and
+// values from a block type that's gone quiet age out instead of sticking.
+type StationState struct {
+	mu sync.Mutex
+
+	connected  bool
+	staleAfter time.Duration
+
+	satellitesUsed    gaugeValue
+	satellitesTracked gaugeValue
+	jammingStatus     gaugeValue
+
+	cpuLoad     gaugeValue
+	temperature gaugeValue
+	uptime      gaugeValue
+	diskFree    gaugeValue
+
+	qualityOverall gaugeValue
+	qualitySignal  gaugeValue
+	qualityRF      gaugeValue
+
+	dopPDOP, dopTDOP, dopHDOP, dopVDOP                gaugeValue
+	posCovLatLat, posCovLonLon, posCovHgtHgt          gaugeValue
+	velCovVnVn, velCovVeVe, velCovVuVu                gaugeValue
+	receiverTimeSyncLevel                             gaugeValue
+	channelsTracking                                  gaugeValue
+	baseVectorNrSV, baseVectorError, baseVectorLength gaugeValue
+
+	satelliteTrackedByConstellation map[string]gaugeValue
+	satelliteSeries                 map[string]*satelliteSeriesValue
+}
+
+// satelliteSeriesValue buffers the per-(svid, signal) gauges MeasEpoch
+// decodes, alongside the label values they're emitted with, so a signal
+// that drops out of view ages out of Collect like every other gauge
+// instead of sticking at its last reading.
+type satelliteSeriesValue struct {
+	svid          string
+	constellation string
+	signal        string
+	cn0           gaugeValue
+	lockTime      gaugeValue
+}
+
+func (st *StationState) set(g *gaugeValue, v float64) {
+	st.mu.Lock()
+	g.update(v)
+	st.mu.Unlock()
+}
+
+func (st *StationState) setConnected(v bool) {
+	st.mu.Lock()
+	st.connected = v
+	st.mu.Unlock()
+}
+
+func (st *StationState) setStaleAfter(d time.Duration) {
+	st.mu.Lock()
+	st.staleAfter = d
+	st.mu.Unlock()
+}
+
+func (st *StationState) setSatellitesUsed(v float64)    { st.set(&st.satellitesUsed, v) }
+func (st *StationState) setSatellitesTracked(v float64) { st.set(&st.satellitesTracked, v) }
+func (st *StationState) setJammingStatus(v float64)     { st.set(&st.jammingStatus, v) }
+
+func (st *StationState) setCPULoad(v float64)     { st.set(&st.cpuLoad, v) }
+func (st *StationState) setTemperature(v float64) { st.set(&st.temperature, v) }
+func (st *StationState) setUptime(v float64)      { st.set(&st.uptime, v) }
+func (st *StationState) setDiskFree(v float64)    { st.set(&st.diskFree, v) }
+
+func (st *StationState) setQualityOverall(v float64) { st.set(&st.qualityOverall, v) }
+func (st *StationState) setQualitySignal(v float64)  { st.set(&st.qualitySignal, v) }
+func (st *StationState) setQualityRF(v float64)      { st.set(&st.qualityRF, v) }
+
+func (st *StationState) setDOPPDOP(v float64) { st.set(&st.dopPDOP, v) }
+func (st *StationState) setDOPTDOP(v float64) { st.set(&st.dopTDOP, v) }
+func (st *StationState) setDOPHDOP(v float64) { st.set(&st.dopHDOP, v) }
+func (st *StationState) setDOPVDOP(v float64) { st.set(&st.dopVDOP, v) }
+
+func (st *StationState) setPosCovLatLat(v float64) { st.set(&st.posCovLatLat, v) }
+func (st *StationState) setPosCovLonLon(v float64) { st.set(&st.posCovLonLon, v) }
+func (st *StationState) setPosCovHgtHgt(v float64) { st.set(&st.posCovHgtHgt, v) }
+
+func (st *StationState) setVelCovVnVn(v float64) { st.set(&st.velCovVnVn, v) }
+func (st *StationState) setVelCovVeVe(v float64) { st.set(&st.velCovVeVe, v) }
+func (st *StationState) setVelCovVuVu(v float64) { st.set(&st.velCovVuVu, v) }
+
+func (st *StationState) setReceiverTimeSyncLevel(v float64) { st.set(&st.receiverTimeSyncLevel, v) }
+func (st *StationState) setChannelsTracking(v float64)      { st.set(&st.channelsTracking, v) }
+
+func (st *StationState) setBaseVectorNrSV(v float64)   { st.set(&st.baseVectorNrSV, v) }
+func (st *StationState) setBaseVectorError(v float64)  { st.set(&st.baseVectorError, v) }
+func (st *StationState) setBaseVectorLength(v float64) { st.set(&st.baseVectorLength, v) }
+
+func (st *StationState) setSatelliteTracked(constellation string, v float64) {
+	st.mu.Lock()
+	if st.satelliteTrackedByConstellation == nil {
+		st.satelliteTrackedByConstellation = map[string]gaugeValue{}
+	}
+	g := st.satelliteTrackedByConstellation[constellation]
+	g.update(v)
+	st.satelliteTrackedByConstellation[constellation] = g
+	st.mu.Unlock()
+}
+
+// setSatelliteSignal records a per-(svid, signal) CN0/lock-time reading,
+// keyed the same way as allowSatelliteSeries's cardinality tracking.
+func (st *StationState) setSatelliteSignal(key, svid, constellation, signal string, cn0, lockTime float64) {
+	st.mu.Lock()
+	if st.satelliteSeries == nil {
+		st.satelliteSeries = map[string]*satelliteSeriesValue{}
+	}
+	s, ok := st.satelliteSeries[key]
+	if !ok {
+		s = &satelliteSeriesValue{svid: svid, constellation: constellation, signal: signal}
+		st.satelliteSeries[key] = s
+	}
+	s.cn0.update(cn0)
+	s.lockTime.update(lockTime)
+	st.mu.Unlock()
+}
+
+var (
+	stationStatesMu sync.Mutex
+	stationStates   = map[string]*StationState{}
+)
+
+// stationState returns the shared StationState for a station, creating it
+// on first use.
+func stationState(name string) *StationState {
+	stationStatesMu.Lock()
+	defer stationStatesMu.Unlock()
+	st, ok := stationStates[name]
+	if !ok {
+		st = &StationState{staleAfter: defaultStaleAfter}
+		stationStates[name] = st
+	}
+	return st
+}
+
+var (
+	satellitesUsedDesc    = prometheus.NewDesc("gnss_satellites_used_total", "Satellites used in solution", []string{"station"}, nil)
+	satellitesTrackedDesc = prometheus.NewDesc("gnss_satellites_tracked_total", "Satellites visible", []string{"station"}, nil)
+	jammingStatusDesc     = prometheus.NewDesc("gnss_jamming_status_code", "0=None, 1=Warning, 2=Critical", []string{"station"}, nil)
+
+	cpuLoadDesc     = prometheus.NewDesc("gnss_cpu_load_percent", "CPU Load (0-100)", []string{"station"}, nil)
+	temperatureDesc = prometheus.NewDesc("gnss_temperature_celsius", "Internal Temperature", []string{"station"}, nil)
+	uptimeDesc      = prometheus.NewDesc("gnss_uptime_seconds", "Receiver Uptime", []string{"station"}, nil)
+	diskFreeDesc    = prometheus.NewDesc("gnss_disk_free_bytes", "Free internal disk space", []string{"station"}, nil)
+
+	qualityOverallDesc = prometheus.NewDesc("gnss_quality_overall", "Overall Quality Indicator (0-10)", []string{"station"}, nil)
+	qualitySignalDesc  = prometheus.NewDesc("gnss_quality_signals", "GNSS Signal Quality (0-10)", []string{"station"}, nil)
+	qualityRFDesc      = prometheus.NewDesc("gnss_quality_rf", "RF Power Quality (0-10)", []string{"station"}, nil)
+
+	dopPDOPDesc = prometheus.NewDesc("gnss_dop_pdop", "Position Dilution of Precision", []string{"station"}, nil)
+	dopTDOPDesc = prometheus.NewDesc("gnss_dop_tdop", "Time Dilution of Precision", []string{"station"}, nil)
+	dopHDOPDesc = prometheus.NewDesc("gnss_dop_hdop", "Horizontal Dilution of Precision", []string{"station"}, nil)
+	dopVDOPDesc = prometheus.NewDesc("gnss_dop_vdop", "Vertical Dilution of Precision", []string{"station"}, nil)
+
+	posCovLatLatDesc = prometheus.NewDesc("gnss_position_cov_lat_lat_m2", "PosCovGeodetic Lat/Lat covariance", []string{"station"}, nil)
+	posCovLonLonDesc = prometheus.NewDesc("gnss_position_cov_lon_lon_m2", "PosCovGeodetic Lon/Lon covariance", []string{"station"}, nil)
+	posCovHgtHgtDesc = prometheus.NewDesc("gnss_position_cov_hgt_hgt_m2", "PosCovGeodetic Height/Height covariance", []string{"station"}, nil)
+
+	velCovVnVnDesc = prometheus.NewDesc("gnss_velocity_cov_north_north", "VelCovGeodetic North/North covariance", []string{"station"}, nil)
+	velCovVeVeDesc = prometheus.NewDesc("gnss_velocity_cov_east_east", "VelCovGeodetic East/East covariance", []string{"station"}, nil)
+	velCovVuVuDesc = prometheus.NewDesc("gnss_velocity_cov_up_up", "VelCovGeodetic Up/Up covariance", []string{"station"}, nil)
+
+	receiverTimeSyncLevelDesc = prometheus.NewDesc("gnss_receiver_time_sync_level", "ReceiverTime synchronization level (0=not synced)", []string{"station"}, nil)
+	channelsTrackingDesc      = prometheus.NewDesc("gnss_channels_tracking", "Receiver channels actively tracking a signal", []string{"station"}, nil)
+
+	baseVectorNrSVDesc   = prometheus.NewDesc("gnss_base_vector_nrsv", "Satellites used in the RTK baseline solution", []string{"station"}, nil)
+	baseVectorErrorDesc  = prometheus.NewDesc("gnss_base_vector_error_code", "BaseVectorGeod per-baseline error code", []string{"station"}, nil)
+	baseVectorLengthDesc = prometheus.NewDesc("gnss_base_vector_length_meters", "RTK baseline length", []string{"station"}, nil)
+
+	satelliteTrackedDesc = prometheus.NewDesc("gnss_satellite_tracked", "Satellites tracked, by constellation", []string{"station", "constellation"}, nil)
+
+	satelliteCN0Desc      = prometheus.NewDesc("gnss_satellite_cn0_dbhz", "Carrier-to-noise density per tracked signal", []string{"station", "svid", "constellation", "signal"}, nil)
+	satelliteLockTimeDesc = prometheus.NewDesc("gnss_satellite_locktime_seconds", "Signal lock time per tracked signal", []string{"station", "svid", "constellation", "signal"}, nil)
+
+	scrapeDurationDesc = prometheus.NewDesc("gnss_scrape_duration_seconds", "Time spent assembling this station's buffered metrics", []string{"station"}, nil)
+	scrapeSuccessDesc  = prometheus.NewDesc("gnss_scrape_success", "1 if the station is connected, 0 otherwise", []string{"station"}, nil)
+)
+
+// StationCollector implements prometheus.Collector for a single station,
+// reading its StationState at Collect time instead of holding live
+// GaugeVecs that get Set() from the stream-reading goroutine.
+type StationCollector struct {
+	station string
+}
+
+func NewStationCollector(station string) *StationCollector {
+	return &StationCollector{station: station}
+}
+
+// Describe intentionally sends nothing: the set of descriptors a station
+// emits depends on which blocks it has decoded (e.g. constellations seen),
+// so this collector is unchecked, same as client_golang's own examples for
+// collectors with a dynamic label set.
+func (c *StationCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (c *StationCollector) Collect(ch chan<- prometheus.Metric) {
+	start := time.Now()
+	st := stationState(c.station)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	ttl := st.staleAfter
+	if ttl <= 0 {
+		ttl = defaultStaleAfter
+	}
+
+	emit := func(desc *prometheus.Desc, g gaugeValue, labels ...string) {
+		if !g.valid(ttl) {
+			return
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, g.value, labels...)
+	}
+
+	emit(satellitesUsedDesc, st.satellitesUsed, c.station)
+	emit(satellitesTrackedDesc, st.satellitesTracked, c.station)
+	emit(jammingStatusDesc, st.jammingStatus, c.station)
+
+	emit(cpuLoadDesc, st.cpuLoad, c.station)
+	emit(temperatureDesc, st.temperature, c.station)
+	emit(uptimeDesc, st.uptime, c.station)
+	emit(diskFreeDesc, st.diskFree, c.station)
+
+	emit(qualityOverallDesc, st.qualityOverall, c.station)
+	emit(qualitySignalDesc, st.qualitySignal, c.station)
+	emit(qualityRFDesc, st.qualityRF, c.station)
+
+	emit(dopPDOPDesc, st.dopPDOP, c.station)
+	emit(dopTDOPDesc, st.dopTDOP, c.station)
+	emit(dopHDOPDesc, st.dopHDOP, c.station)
+	emit(dopVDOPDesc, st.dopVDOP, c.station)
+
+	emit(posCovLatLatDesc, st.posCovLatLat, c.station)
+	emit(posCovLonLonDesc, st.posCovLonLon, c.station)
+	emit(posCovHgtHgtDesc, st.posCovHgtHgt, c.station)
+
+	emit(velCovVnVnDesc, st.velCovVnVn, c.station)
+	emit(velCovVeVeDesc, st.velCovVeVe, c.station)
+	emit(velCovVuVuDesc, st.velCovVuVu, c.station)
+
+	emit(receiverTimeSyncLevelDesc, st.receiverTimeSyncLevel, c.station)
+	emit(channelsTrackingDesc, st.channelsTracking, c.station)
+
+	emit(baseVectorNrSVDesc, st.baseVectorNrSV, c.station)
+	emit(baseVectorErrorDesc, st.baseVectorError, c.station)
+	emit(baseVectorLengthDesc, st.baseVectorLength, c.station)
+
+	for constellation, g := range st.satelliteTrackedByConstellation {
+		emit(satelliteTrackedDesc, g, c.station, constellation)
+	}
+
+	for _, s := range st.satelliteSeries {
+		emit(satelliteCN0Desc, s.cn0, c.station, s.svid, s.constellation, s.signal)
+		emit(satelliteLockTimeDesc, s.lockTime, c.station, s.svid, s.constellation, s.signal)
+	}
+
+	success := 0.0
+	if st.connected {
+		success = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, c.station)
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(start).Seconds(), c.station)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectDescs runs a collector's Collect and returns the fully-qualified
+// metric names (via Desc.String(), which is stable enough to substring
+// match on) it emitted.
+func collectMetrics(c prometheus.Collector) []prometheus.Metric {
+	ch := make(chan prometheus.Metric, 64)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	var out []prometheus.Metric
+	for m := range ch {
+		out = append(out, m)
+	}
+	return out
+}
+
+func TestStationCollectorAgesOutStaleSatelliteSeries(t *testing.T) {
+	station := "agestation"
+	st := stationState(station)
+	st.setStaleAfter(10 * time.Millisecond)
+	st.setSatelliteSignal("12|l1ca", "12", "gps", "l1ca", 40.0, 3600)
+
+	collector := NewStationCollector(station)
+
+	fresh := collectMetrics(collector)
+	if !anyMetricMatches(fresh, "gnss_satellite_cn0_dbhz") {
+		t.Fatal("expected gnss_satellite_cn0_dbhz while the reading is fresh")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	aged := collectMetrics(collector)
+	if anyMetricMatches(aged, "gnss_satellite_cn0_dbhz") {
+		t.Error("gnss_satellite_cn0_dbhz should not be emitted once the reading is stale")
+	}
+	if anyMetricMatches(aged, "gnss_satellite_locktime_seconds") {
+		t.Error("gnss_satellite_locktime_seconds should not be emitted once the reading is stale")
+	}
+}
+
+func TestPruneStaleSatelliteSeriesReclaimsCardinality(t *testing.T) {
+	station := "prunestation"
+	st := stationState(station)
+	st.setStaleAfter(10 * time.Millisecond)
+
+	recordSatelliteSignal(station, 1, "gps", "l1ca", 40.0, 100, 1)
+
+	// Limit is 1 and svid 1 already holds the slot, so svid 2 is rejected.
+	recordSatelliteSignal(station, 2, "gps", "l1ca", 41.0, 100, 1)
+	if _, ok := st.satelliteSeries["2|l1ca"]; ok {
+		t.Fatal("svid 2's series should have been rejected by the cardinality limit")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	// svid 1's series is now stale; recording svid 2 again should reclaim its slot.
+	recordSatelliteSignal(station, 2, "gps", "l1ca", 41.0, 100, 1)
+	if _, ok := st.satelliteSeries["2|l1ca"]; !ok {
+		t.Error("expected svid 2's series once svid 1's stale series was pruned")
+	}
+	if _, ok := st.satelliteSeries["1|l1ca"]; ok {
+		t.Error("svid 1's stale series should have been pruned from StationState")
+	}
+}
+
+func anyMetricMatches(metrics []prometheus.Metric, nameSubstring string) bool {
+	for _, m := range metrics {
+		if strings.Contains(m.Desc().String(), nameSubstring) {
+			return true
+		}
+	}
+	return false
+}
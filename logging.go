@@ -0,0 +1,232 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/ricochet2200/go-disk-usage/du"
+)
+
+// defaultLogMaxSizeMB caps a station's active SBF log segment size, absent
+// an explicit StationConfig.Logging.MaxSizeMB.
+const defaultLogMaxSizeMB = 100
+
+var (
+	logBytesWrittenTotal = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "gnss_log_bytes_written_total", Help: "Bytes written to the station's SBF log"}, []string{"station"})
+	logRotationsTotal    = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "gnss_log_rotations_total", Help: "SBF log segments rotated"}, []string{"station"})
+	logDiskUsageBytes    = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_log_disk_usage_bytes", Help: "Size of the active SBF log segment"}, []string{"station"})
+	logDiskFreeBytes     = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_log_disk_free_bytes", Help: "Free space on the filesystem backing the SBF log directory"}, []string{"station"})
+)
+
+func init() {
+	prometheus.MustRegister(logBytesWrittenTotal, logRotationsTotal, logDiskUsageBytes, logDiskFreeBytes)
+}
+
+var (
+	sbfLoggersMu sync.Mutex
+	sbfLoggers   = map[string]*sbfLogger{}
+)
+
+// configureSBFLogger sets up (or disables) SBF frame logging for a station
+// from its StationConfig.Logging, at startup.
+func configureSBFLogger(station string, cfg LoggingConfig) {
+	l, err := newSBFLogger(station, cfg)
+	if err != nil {
+		log.Printf("[%s] sbf log: %v", station, err)
+		return
+	}
+	sbfLoggersMu.Lock()
+	sbfLoggers[station] = l
+	sbfLoggersMu.Unlock()
+}
+
+// sbfLoggerFor returns the station's configured logger, or nil if logging
+// isn't enabled for it.
+func sbfLoggerFor(station string) *sbfLogger {
+	sbfLoggersMu.Lock()
+	defer sbfLoggersMu.Unlock()
+	return sbfLoggers[station]
+}
+
+// sbfLogger writes a byte-identical copy of every validated SBF frame
+// (sync chars + header + payload) to a rotating, optionally gzip-compressed
+// log file, so captures can be post-processed with standard Septentrio
+// tools.
+type sbfLogger struct {
+	mu sync.Mutex
+
+	station  string
+	dir      string
+	maxSize  int64
+	maxAge   time.Duration
+	compress bool
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// newSBFLogger opens (or creates) today's segment for the station. A nil
+// return means the station has no logging configured.
+func newSBFLogger(station string, cfg LoggingConfig) (*sbfLogger, error) {
+	if cfg.Dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	maxSize := int64(cfg.MaxSizeMB) * 1024 * 1024
+	if maxSize <= 0 {
+		maxSize = defaultLogMaxSizeMB * 1024 * 1024
+	}
+	maxAge := time.Duration(cfg.MaxAgeHours) * time.Hour
+
+	l := &sbfLogger{
+		station:  station,
+		dir:      cfg.Dir,
+		maxSize:  maxSize,
+		maxAge:   maxAge,
+		compress: cfg.Compress,
+	}
+	if err := l.openSegment(); err != nil {
+		return nil, err
+	}
+	if maxAge > 0 {
+		go l.ageRotationLoop()
+	}
+	return l, nil
+}
+
+func (l *sbfLogger) segmentPath() string {
+	return filepath.Join(l.dir, fmt.Sprintf("%s.sbf", l.station))
+}
+
+func (l *sbfLogger) openSegment() error {
+	f, err := os.OpenFile(l.segmentPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.file = f
+	l.size = info.Size()
+	l.openedAt = time.Now()
+	l.reportDiskMetrics()
+	return nil
+}
+
+// write appends a frame, rotating first if it would push the active
+// segment past the configured size limit.
+func (l *sbfLogger) write(frame []byte) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.size+int64(len(frame)) > l.maxSize {
+		l.rotateLocked()
+	}
+
+	n, err := l.file.Write(frame)
+	if err != nil {
+		return
+	}
+	l.size += int64(n)
+	logBytesWrittenTotal.WithLabelValues(l.station).Add(float64(n))
+	logDiskUsageBytes.WithLabelValues(l.station).Set(float64(l.size))
+}
+
+// ageRotationLoop periodically rotates the active segment once it's older
+// than maxAge, independent of size. It rotates off the segment's own open
+// time rather than the file's mtime: a streaming station rewrites the file
+// constantly, which would otherwise keep bumping mtime and mean age-based
+// rotation never fires.
+func (l *sbfLogger) ageRotationLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.mu.Lock()
+		if l.ageExceededLocked() && l.size > 0 {
+			l.rotateLocked()
+		}
+		l.mu.Unlock()
+	}
+}
+
+// ageExceededLocked reports whether the active segment has been open longer
+// than maxAge. Callers must hold l.mu.
+func (l *sbfLogger) ageExceededLocked() bool {
+	return time.Since(l.openedAt) > l.maxAge
+}
+
+// rotateLocked closes the active segment, renames it to a timestamped name,
+// opens a fresh segment, and (if configured) compresses the rotated-out
+// segment in the background. Callers must hold l.mu.
+func (l *sbfLogger) rotateLocked() {
+	l.file.Close()
+
+	rotated := filepath.Join(l.dir, fmt.Sprintf("%s-%s.sbf", l.station, time.Now().UTC().Format("20060102T150405Z")))
+	if err := os.Rename(l.segmentPath(), rotated); err != nil {
+		l.openSegment()
+		return
+	}
+
+	logRotationsTotal.WithLabelValues(l.station).Inc()
+	if l.compress {
+		go compressSegment(rotated)
+	}
+
+	l.openSegment()
+}
+
+func (l *sbfLogger) reportDiskMetrics() {
+	logDiskUsageBytes.WithLabelValues(l.station).Set(float64(l.size))
+	if usage := du.NewDiskUsage(l.dir); usage != nil {
+		logDiskFreeBytes.WithLabelValues(l.station).Set(float64(usage.Free()))
+	}
+}
+
+// compressSegment gzips a rotated-out log segment and removes the
+// uncompressed original, logging but otherwise ignoring failures since the
+// uncompressed segment is still a valid capture on its own.
+func compressSegment(path string) {
+	in, err := os.Open(path)
+	if err != nil {
+		log.Printf("sbf log: compress %s: %v", path, err)
+		return
+	}
+	defer in.Close()
+
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		log.Printf("sbf log: compress %s: %v", path, err)
+		return
+	}
+	defer out.Close()
+
+	gw := gzip.NewWriter(out)
+	if _, err := io.Copy(gw, in); err != nil {
+		log.Printf("sbf log: compress %s: %v", path, err)
+		return
+	}
+	if err := gw.Close(); err != nil {
+		log.Printf("sbf log: compress %s: %v", path, err)
+		return
+	}
+
+	os.Remove(path)
+}
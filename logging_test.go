@@ -0,0 +1,187 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestSBFLogger builds an sbfLogger directly (bypassing newSBFLogger's
+// MB-granularity config) so tests can use byte-sized limits.
+func newTestSBFLogger(t *testing.T, maxSize int64, maxAge time.Duration, compress bool) *sbfLogger {
+	t.Helper()
+	dir := t.TempDir()
+	l := &sbfLogger{
+		station:  "teststation-" + t.Name(),
+		dir:      dir,
+		maxSize:  maxSize,
+		maxAge:   maxAge,
+		compress: compress,
+	}
+	if err := l.openSegment(); err != nil {
+		t.Fatalf("openSegment: %v", err)
+	}
+	t.Cleanup(func() { l.file.Close() })
+	return l
+}
+
+// rotatedSegments lists everything in dir except the logger's active
+// segment -- i.e. the rotated-out (and possibly compressed) files.
+func rotatedSegments(t *testing.T, l *sbfLogger) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(l.dir, "*"))
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	var out []string
+	for _, m := range matches {
+		if m != l.segmentPath() {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func TestSBFLoggerRotatesOnSize(t *testing.T) {
+	l := newTestSBFLogger(t, 10, 0, false)
+
+	l.write([]byte("0123456789")) // exactly fills the segment, no rotation yet
+	l.write([]byte("x"))          // pushes size over the limit, rotates first
+
+	rotated := rotatedSegments(t, l)
+	if len(rotated) != 1 {
+		t.Fatalf("got %d rotated segments, want 1: %v", len(rotated), rotated)
+	}
+	rotatedData, err := os.ReadFile(rotated[0])
+	if err != nil {
+		t.Fatalf("reading rotated segment: %v", err)
+	}
+	if string(rotatedData) != "0123456789" {
+		t.Errorf("rotated segment content = %q, want %q", rotatedData, "0123456789")
+	}
+
+	activeData, err := os.ReadFile(l.segmentPath())
+	if err != nil {
+		t.Fatalf("reading active segment: %v", err)
+	}
+	if string(activeData) != "x" {
+		t.Errorf("active segment content = %q, want %q", activeData, "x")
+	}
+
+	if got := testCounterValue(t, logRotationsTotal, l.station); got != 1 {
+		t.Errorf("gnss_log_rotations_total = %v, want 1", got)
+	}
+}
+
+func TestSBFLoggerAgeRotationIgnoresFileMtime(t *testing.T) {
+	l := newTestSBFLogger(t, 1<<20, time.Hour, false) // maxSize large enough that only age can trigger
+
+	// Repeated writes bump the file's mtime, same as an actively streaming
+	// station would -- this is what made the old ModTime()-based check
+	// never fire. The segment's own open time is what should govern.
+	l.write([]byte("a"))
+	l.write([]byte("b"))
+	l.openedAt = time.Now().Add(-2 * time.Hour)
+
+	l.mu.Lock()
+	exceeded := l.ageExceededLocked()
+	if exceeded && l.size > 0 {
+		l.rotateLocked()
+	}
+	l.mu.Unlock()
+
+	if !exceeded {
+		t.Fatal("ageExceededLocked() = false, want true once openedAt is older than maxAge")
+	}
+	if rotated := rotatedSegments(t, l); len(rotated) != 1 {
+		t.Fatalf("got %d rotated segments after age rotation, want 1: %v", len(rotated), rotated)
+	}
+}
+
+func TestSBFLoggerAgeRotationDoesNotFireEarly(t *testing.T) {
+	l := newTestSBFLogger(t, 1<<20, time.Hour, false)
+	l.write([]byte("a"))
+
+	l.mu.Lock()
+	exceeded := l.ageExceededLocked()
+	l.mu.Unlock()
+
+	if exceeded {
+		t.Error("ageExceededLocked() = true for a freshly opened segment, want false")
+	}
+}
+
+func TestCompressSegmentGzipsAndRemovesOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "station.sbf")
+	want := []byte("some captured SBF bytes")
+	if err := os.WriteFile(path, want, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	compressSegment(path)
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("original segment still exists after compression (err=%v)", err)
+	}
+
+	gz, err := os.Open(path + ".gz")
+	if err != nil {
+		t.Fatalf("opening .gz output: %v", err)
+	}
+	defer gz.Close()
+
+	gr, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	defer gr.Close()
+
+	got, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("reading gzip contents: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("decompressed content = %q, want %q", got, want)
+	}
+}
+
+func TestSBFLoggerRotationCompressesWhenConfigured(t *testing.T) {
+	l := newTestSBFLogger(t, 5, 0, true)
+	l.write([]byte("01234"))
+	l.write([]byte("x")) // triggers rotation + background compression
+
+	deadline := time.Now().Add(2 * time.Second)
+	var gzFound, plainGone bool
+	for time.Now().Before(deadline) {
+		matches, _ := filepath.Glob(filepath.Join(l.dir, "*.sbf.gz"))
+		if len(matches) == 1 {
+			gzFound = true
+			plain := matches[0][:len(matches[0])-len(".gz")]
+			if _, err := os.Stat(plain); os.IsNotExist(err) {
+				plainGone = true
+			}
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !gzFound {
+		t.Fatal("rotated segment was never gzip-compressed")
+	}
+	if !plainGone {
+		t.Error("uncompressed rotated segment should be removed once compressed")
+	}
+}
+
+func TestSBFLoggerReportsDiskUsageMetric(t *testing.T) {
+	l := newTestSBFLogger(t, 1<<20, 0, false)
+	l.write([]byte("12345"))
+
+	if got := testGaugeValue(t, logDiskUsageBytes, l.station); got != 5 {
+		t.Errorf("gnss_log_disk_usage_bytes = %v, want 5", got)
+	}
+}
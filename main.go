@@ -25,49 +25,55 @@ type ServerConfig struct {
 	Port int `yaml:"port"`
 }
 type StationConfig struct {
-	Name string `yaml:"name"`
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
+	Name              string        `yaml:"name"`
+	Host              string        `yaml:"host"`
+	Port              int           `yaml:"port"`
+	Transport         string        `yaml:"transport"` // "tcp" (default), "serial", or "file"
+	Protocol          string        `yaml:"protocol"`  // "tcp" (default) or "ntrip"; only meaningful for transport "tcp"
+	Ntrip             NtripConfig   `yaml:"ntrip"`
+	Serial            SerialConfig  `yaml:"serial"`
+	File              FileConfig    `yaml:"file"`
+	MaxTrackedSignals int           `yaml:"max_tracked_signals"` // caps per-satellite/per-signal label cardinality, 0 = default
+	StaleAfterSeconds int           `yaml:"stale_after_seconds"` // how long block-derived gauges stay valid with no fresh data, 0 = default
+	Logging           LoggingConfig `yaml:"logging"`
+}
+
+// LoggingConfig enables an on-disk, rotating capture of the station's raw
+// SBF stream, byte-identical to what the receiver emits. Logging is
+// disabled unless Dir is set.
+type LoggingConfig struct {
+	Dir         string `yaml:"dir"`
+	MaxSizeMB   int    `yaml:"max_size_mb"`   // rotate once the active segment exceeds this, 0 = default
+	MaxAgeHours int    `yaml:"max_age_hours"` // also rotate once the active segment is this old, 0 = no age-based rotation
+	Compress    bool   `yaml:"compress"`      // gzip rotated-out segments
 }
 
 // --- Constants ---
 const (
 	SyncChar1 = '$'
 	SyncChar2 = '@'
-
-	// Block IDs
-	BlockID_PVTGeodetic    = 4007
-	BlockID_ReceiverStatus = 4014 // CPU, Uptime, Temp
-	BlockID_MeasEpoch      = 4027
-	BlockID_DiskStatus     = 4059 // Disk Space
-	BlockID_QualityInd     = 4082 // Quality Indicators (0-10)
-	BlockID_RFStatus       = 4092
 )
 
 // --- Metrics ---
 var (
-	// Existing
-	satellitesTracked = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_satellites_tracked_total", Help: "Satellites visible"}, []string{"station"})
-	satellitesUsed    = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_satellites_used_total", Help: "Satellites used in solution"}, []string{"station"})
-	jammingStatus     = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_jamming_status_code", Help: "0=None, 1=Warning, 2=Critical"}, []string{"station"})
 	receiverConnected = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_receiver_connected", Help: "Connection status"}, []string{"station"})
 
-	// NEW: System Health
-	cpuLoad     = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_cpu_load_percent", Help: "CPU Load (0-100)"}, []string{"station"})
-	temperature = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_temperature_celsius", Help: "Internal Temperature"}, []string{"station"})
-	uptime      = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_uptime_seconds", Help: "Receiver Uptime"}, []string{"station"})
-	diskFree    = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_disk_free_bytes", Help: "Free internal disk space"}, []string{"station"})
+	// NTRIP client + RTCM3 stream
+	ntripConnected          = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_ntrip_connected", Help: "NTRIP caster connection status"}, []string{"station"})
+	ntripBytesReceivedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "gnss_ntrip_bytes_received_total", Help: "Bytes received from the NTRIP caster"}, []string{"station"})
+	ntripLastMessageAge     = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_ntrip_last_message_age_seconds", Help: "Time since the last byte was received from the NTRIP caster"}, []string{"station"})
+	rtcmMessagesTotal       = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "gnss_rtcm_messages_total", Help: "RTCM3 messages received, by message type"}, []string{"station", "type"})
 
-	// NEW: Quality Indicators (0-10 scale)
-	qualityOverall = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_quality_overall", Help: "Overall Quality Indicator (0-10)"}, []string{"station"})
-	qualitySignal  = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_quality_signals", Help: "GNSS Signal Quality (0-10)"}, []string{"station"})
-	qualityRF      = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_quality_rf", Help: "RF Power Quality (0-10)"}, []string{"station"})
+	ntripSourceTableMountInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_ntrip_sourcetable_mount_info", Help: "Constant 1 per mountpoint reported in the caster's source table"}, []string{"station", "mountpoint", "format", "carrier", "nav_system", "country"})
+
+	transportInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "gnss_transport_info", Help: "Constant 1, labelled with the configured transport and endpoint"}, []string{"station", "type", "endpoint"})
 )
 
 func init() {
-	prometheus.MustRegister(satellitesTracked, satellitesUsed, jammingStatus, receiverConnected)
-	prometheus.MustRegister(cpuLoad, temperature, uptime, diskFree)
-	prometheus.MustRegister(qualityOverall, qualitySignal, qualityRF)
+	prometheus.MustRegister(receiverConnected)
+	prometheus.MustRegister(ntripConnected, ntripBytesReceivedTotal, ntripLastMessageAge, rtcmMessagesTotal)
+	prometheus.MustRegister(ntripSourceTableMountInfo)
+	prometheus.MustRegister(transportInfo)
 }
 
 func main() {
@@ -77,6 +83,21 @@ func main() {
 	}
 
 	for _, s := range cfg.Stations {
+		setSatelliteCardinalityLimit(s.Name, s.MaxTrackedSignals)
+
+		staleAfter := defaultStaleAfter
+		if s.StaleAfterSeconds > 0 {
+			staleAfter = time.Duration(s.StaleAfterSeconds) * time.Second
+		}
+		stationState(s.Name).setStaleAfter(staleAfter)
+		prometheus.MustRegister(NewStationCollector(s.Name))
+		configureSBFLogger(s.Name, s.Logging)
+
+		if s.Protocol == "ntrip" && s.Ntrip.SourceTable {
+			go fetchNTRIPSourceTableOnce(s)
+			continue
+		}
+
 		go monitorStation(s)
 	}
 
@@ -100,28 +121,71 @@ func monitorStation(s StationConfig) {
 	address := fmt.Sprintf("%s:%d", s.Host, s.Port)
 	logger := log.New(log.Writer(), fmt.Sprintf("[%s] ", s.Name), log.LstdFlags)
 
+	transport := s.Transport
+	if transport == "" {
+		transport = "tcp"
+	}
+	isNtrip := transport == "tcp" && s.Protocol == "ntrip"
+
+	transportInfo.WithLabelValues(s.Name, transport, transportEndpoint(transport, s, address)).Set(1)
+
 	for {
 		receiverConnected.WithLabelValues(s.Name).Set(0)
+		stationState(s.Name).setConnected(false)
+		if isNtrip {
+			ntripConnected.WithLabelValues(s.Name).Set(0)
+		}
 
-		conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+		var conn io.ReadCloser
+		var err error
+		switch transport {
+		case "serial":
+			conn, err = dialSerial(s.Serial)
+		case "file":
+			conn, err = openFileReplay(s.File)
+		default:
+			if isNtrip {
+				conn, err = dialNTRIP(address, s.Ntrip, logger)
+			} else {
+				conn, err = net.DialTimeout("tcp", address, 5*time.Second)
+			}
+		}
 		if err != nil {
-			logger.Printf("Connection failed. Retrying in 10s...")
+			logger.Printf("Connection failed: %v. Retrying in 10s...", err)
 			time.Sleep(10 * time.Second)
 			continue
 		}
 
 		receiverConnected.WithLabelValues(s.Name).Set(1)
+		stationState(s.Name).setConnected(true)
 		logger.Printf("Connected to %s", s.Name)
-		handleStream(conn, s.Name, logger)
+
+		var stream io.Reader = conn
+		var done chan struct{}
+		if isNtrip {
+			ntripConnected.WithLabelValues(s.Name).Set(1)
+			stream = &meteredReader{r: conn, station: s.Name}
+			done = make(chan struct{})
+			go ntripAgeUpdater(s.Name, done)
+		}
+
+		handleStream(stream, s.Name, logger)
 		conn.Close()
+		if done != nil {
+			close(done)
+		}
 
+		stationState(s.Name).setConnected(false)
+		if isNtrip {
+			ntripConnected.WithLabelValues(s.Name).Set(0)
+		}
 		logger.Printf("Connection lost. Reconnecting...")
 		time.Sleep(5 * time.Second)
 	}
 }
 
-func handleStream(conn net.Conn, stationName string, log *log.Logger) {
-	reader := bufio.NewReader(conn)
+func handleStream(r io.Reader, stationName string, log *log.Logger) {
+	reader := bufio.NewReader(r)
 	headerBuf := make([]byte, 8)
 
 	for {
@@ -129,6 +193,14 @@ func handleStream(conn net.Conn, stationName string, log *log.Logger) {
 		if err != nil {
 			return
 		}
+
+		if b == RTCMPreamble {
+			if !handleRTCM3Frame(reader, stationName, log) {
+				return
+			}
+			continue
+		}
+
 		if b != SyncChar1 {
 			continue
 		}
@@ -147,6 +219,7 @@ func handleStream(conn net.Conn, stationName string, log *log.Logger) {
 		idRaw := binary.LittleEndian.Uint16(headerBuf[4:6])
 		length := binary.LittleEndian.Uint16(headerBuf[6:8])
 		baseID := idRaw & 0x1FFF
+		revision := uint8(idRaw >> 13)
 
 		if length < 8 || length > 8192 {
 			continue
@@ -158,85 +231,20 @@ func handleStream(conn net.Conn, stationName string, log *log.Logger) {
 			return
 		}
 
-		parseBlock(stationName, baseID, payload, log)
-	}
-}
-
-func parseBlock(stationName string, id uint16, payload []byte, log *log.Logger) {
-	switch id {
-	case BlockID_PVTGeodetic: // 4007
-		if len(payload) > 66 {
-			nrSv := payload[66]
-			satellitesUsed.WithLabelValues(stationName).Set(float64(nrSv))
-		}
-
-	case BlockID_MeasEpoch: // 4027
-		if len(payload) > 6 {
-			n := payload[6]
-			satellitesTracked.WithLabelValues(stationName).Set(float64(n))
-		}
-
-	case BlockID_RFStatus: // 4092
-		if len(payload) > 8 {
-			flags := payload[8]
-			state := 0.0
-			if (flags & 0x01) != 0 {
-				state = 1.0
-			}
-			if (flags & 0x02) != 0 {
-				state = 2.0
-			}
-			jammingStatus.WithLabelValues(stationName).Set(state)
-		}
-
-	case BlockID_ReceiverStatus: // 4014
-		// Layout: CPULoad(u8, off=6), Uptime(u32, off=7), ... Temp(u8, off=15 typically)
-		// Note: Offsets can vary by firmware revision.
-		if len(payload) >= 16 {
-			// CPU Load (Offset 6)
-			cpu := payload[6]
-			cpuLoad.WithLabelValues(stationName).Set(float64(cpu))
-
-			// Uptime (Offset 7, u32)
-			up := binary.LittleEndian.Uint32(payload[7:11])
-			uptime.WithLabelValues(stationName).Set(float64(up))
-
-			// Temperature (Offset 15 often contains temp in Celsius)
-			// Note: Some firmwares place it elsewhere. If this reads weird, let me know.
-			temp := int8(payload[15])
-			temperature.WithLabelValues(stationName).Set(float64(temp))
+		crcExpected := binary.LittleEndian.Uint16(headerBuf[2:4])
+		if sbfCRC16(headerBuf[4:8], payload) != crcExpected {
+			sbfCRCErrorsTotal.WithLabelValues(stationName).Inc()
+			continue
 		}
 
-	case BlockID_QualityInd: // 4082
-		// Layout: Overall(u8), GNSS(u8), RF(u8) ... (offsets vary, usually start at 6 after headers)
-		// Payload: TOW(4) + WNc(2) + Qualities...
-		if len(payload) >= 9 {
-			// Offset 6: Overall Quality (0-10)
-			qOver := payload[6]
-			qualityOverall.WithLabelValues(stationName).Set(float64(qOver))
-
-			// Offset 7: GNSS Signals (0-10)
-			qSig := payload[7]
-			qualitySignal.WithLabelValues(stationName).Set(float64(qSig))
-
-			// Offset 8: RF Power (0-10)
-			qRF := payload[8]
-			qualityRF.WithLabelValues(stationName).Set(float64(qRF))
+		if logger := sbfLoggerFor(stationName); logger != nil {
+			frame := make([]byte, 0, int(length))
+			frame = append(frame, SyncChar1, SyncChar2)
+			frame = append(frame, headerBuf[2:]...)
+			frame = append(frame, payload...)
+			logger.write(frame)
 		}
 
-	case BlockID_DiskStatus: // 4059
-		// Layout: N(u8), SB(u8), [DiskID, Capacity(u32), Used(u32)]
-		// Payload: TOW(4) + WNc(2) + N(1) + SB(1) + [Disk Data...]
-		if len(payload) >= 20 {
-			// Offset 8: DiskID
-			// Offset 9-12: Capacity (MB) - u32
-			// Offset 13-16: Used (MB) - u32
-
-			capacityMB := binary.LittleEndian.Uint32(payload[9:13])
-			usedMB := binary.LittleEndian.Uint32(payload[13:17])
-
-			freeBytes := float64(capacityMB-usedMB) * 1024 * 1024
-			diskFree.WithLabelValues(stationName).Set(freeBytes)
-		}
+		dispatchBlock(stationName, baseID, revision, payload, log)
 	}
 }
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// defaultMaxTrackedSignals caps the number of distinct (svid, signal) label
+// combinations a station will export per-satellite metrics for, absent an
+// explicit StationConfig.MaxTrackedSignals. It guards against cardinality
+// blowups on receivers tracking many constellations/signals at once.
+const defaultMaxTrackedSignals = 128
+
+// decodeMeasEpoch walks the MeasEpoch sub-block structure: a common header
+// (N1, SB1Length, SB2Length, CommonFlags, CumClkJumps, Reserved) followed by
+// N1 Type-1 sub-blocks (one per tracked satellite), each of which may be
+// followed by its own N2 Type-2 sub-blocks carrying additional signals
+// tracked on the same SVID.
+func decodeMeasEpoch(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	if len(payload) < 12 {
+		return
+	}
+
+	n1 := int(payload[6])
+	sb1Len := int(payload[7])
+	sb2Len := int(payload[8])
+	if sb1Len <= 0 {
+		return
+	}
+
+	stationState(stationName).setSatellitesTracked(float64(n1))
+
+	limit := satelliteCardinalityLimit(stationName)
+	perConstellation := map[string]int{}
+
+	offset := 12
+	for i := 0; i < n1 && offset+sb1Len <= len(payload); i++ {
+		sub := payload[offset : offset+sb1Len]
+		offset += sb1Len
+
+		if len(sub) < 16 {
+			continue
+		}
+
+		svid := sub[2]
+		signalType := sub[1] & 0x1F
+		n2 := int(sub[3] & 0x0F)
+		cn0 := float64(sub[12]) * 0.25
+		lockTime := float64(binary.LittleEndian.Uint16(sub[13:15]))
+
+		constellation := constellationForSVID(svid)
+		perConstellation[constellation]++
+		recordSatelliteSignal(stationName, svid, constellation, signalTypeName(signalType), cn0, lockTime, limit)
+
+		for j := 0; j < n2 && offset+sb2Len <= len(payload); j++ {
+			sub2 := payload[offset : offset+sb2Len]
+			offset += sb2Len
+
+			if len(sub2) < 3 {
+				continue
+			}
+			signalType2 := sub2[0] & 0x1F
+			cn02 := float64(sub2[2]) * 0.25
+			recordSatelliteSignal(stationName, svid, constellation, signalTypeName(signalType2), cn02, lockTime, limit)
+		}
+	}
+
+	for constellation, count := range perConstellation {
+		stationState(stationName).setSatelliteTracked(constellation, float64(count))
+	}
+}
+
+func recordSatelliteSignal(station string, svid uint8, constellation, signal string, cn0, lockTime float64, limit int) {
+	pruneStaleSatelliteSeries(station)
+
+	key := fmt.Sprintf("%d|%s", svid, signal)
+	if !allowSatelliteSeries(station, key, limit) {
+		return
+	}
+	svidLabel := fmt.Sprintf("%d", svid)
+	stationState(station).setSatelliteSignal(key, svidLabel, constellation, signal, cn0, lockTime)
+}
+
+// pruneStaleSatelliteSeries drops (svid, signal) series that have aged out
+// of StationState from trackedSeries's cardinality bookkeeping, so a
+// satellite that drops out of view eventually frees its slot for a new one
+// instead of permanently squatting on the station's cardinality limit.
+func pruneStaleSatelliteSeries(station string) {
+	st := stationState(station)
+
+	st.mu.Lock()
+	ttl := st.staleAfter
+	if ttl <= 0 {
+		ttl = defaultStaleAfter
+	}
+	var stale []string
+	for key, s := range st.satelliteSeries {
+		if !s.cn0.valid(ttl) && !s.lockTime.valid(ttl) {
+			stale = append(stale, key)
+			delete(st.satelliteSeries, key)
+		}
+	}
+	st.mu.Unlock()
+
+	if len(stale) == 0 {
+		return
+	}
+	trackedSeriesMu.Lock()
+	if set, ok := trackedSeries[station]; ok {
+		for _, key := range stale {
+			delete(set, key)
+		}
+	}
+	trackedSeriesMu.Unlock()
+}
+
+// constellationForSVID maps an SVID to its constellation per the SBF
+// reference guide's satellite numbering ranges.
+func constellationForSVID(svid uint8) string {
+	switch {
+	case svid >= 1 && svid <= 37:
+		return "gps"
+	case svid >= 38 && svid <= 61:
+		return "glonass"
+	case svid >= 71 && svid <= 106:
+		return "galileo"
+	case svid >= 120 && svid <= 140:
+		return "sbas"
+	case svid >= 141 && svid <= 180:
+		return "beidou"
+	case svid >= 181 && svid <= 187:
+		return "qzss"
+	case svid >= 198 && svid <= 215:
+		return "irnss"
+	default:
+		return "unknown"
+	}
+}
+
+// signalTypeName maps the MeasEpoch signal type field to a short label.
+// Not every Septentrio signal type code is enumerated; unknown codes fall
+// back to a numeric label rather than being dropped.
+func signalTypeName(t uint8) string {
+	switch t {
+	case 0:
+		return "l1ca"
+	case 1:
+		return "l1p"
+	case 2:
+		return "l2p"
+	case 3:
+		return "l2c"
+	case 4:
+		return "l5"
+	case 5:
+		return "l1c"
+	case 8:
+		return "glo_l1ca"
+	case 9:
+		return "glo_l2ca"
+	case 10:
+		return "glo_l1p"
+	case 11:
+		return "glo_l2p"
+	case 15:
+		return "gal_e1"
+	case 16:
+		return "gal_e6"
+	case 17:
+		return "gal_e5a"
+	case 18:
+		return "gal_e5b"
+	case 19:
+		return "gal_e5altboc"
+	case 20:
+		return "bds_b1i"
+	case 21:
+		return "bds_b2i"
+	case 22:
+		return "bds_b3i"
+	case 26:
+		return "sbas_l1"
+	case 27:
+		return "sbas_l5"
+	default:
+		return fmt.Sprintf("type_%d", t)
+	}
+}
+
+var (
+	satelliteLimitMu sync.Mutex
+	satelliteLimits  = map[string]int{}
+
+	trackedSeriesMu sync.Mutex
+	trackedSeries   = map[string]map[string]struct{}{}
+)
+
+// setSatelliteCardinalityLimit records the per-satellite/per-signal series
+// cap for a station, read from StationConfig.MaxTrackedSignals at startup.
+func setSatelliteCardinalityLimit(station string, limit int) {
+	if limit <= 0 {
+		limit = defaultMaxTrackedSignals
+	}
+	satelliteLimitMu.Lock()
+	satelliteLimits[station] = limit
+	satelliteLimitMu.Unlock()
+}
+
+func satelliteCardinalityLimit(station string) int {
+	satelliteLimitMu.Lock()
+	defer satelliteLimitMu.Unlock()
+	if l, ok := satelliteLimits[station]; ok {
+		return l
+	}
+	return defaultMaxTrackedSignals
+}
+
+// allowSatelliteSeries reports whether (station, key) may get its own
+// metric series: already-seen keys are always allowed through so their
+// gauges keep updating, new keys are rejected once the station's
+// cardinality limit has been reached.
+func allowSatelliteSeries(station, key string, limit int) bool {
+	trackedSeriesMu.Lock()
+	defer trackedSeriesMu.Unlock()
+
+	set, ok := trackedSeries[station]
+	if !ok {
+		set = map[string]struct{}{}
+		trackedSeries[station] = set
+	}
+	if _, exists := set[key]; exists {
+		return true
+	}
+	if len(set) >= limit {
+		return false
+	}
+	set[key] = struct{}{}
+	return true
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// buildMeasEpochPayload assembles a minimal MeasEpoch payload for one
+// tracked satellite with a Type-1 sub-block plus one nested Type-2
+// sub-block, so decode coverage exercises both levels of nesting.
+func buildMeasEpochPayload() []byte {
+	const sb1Len = 20
+	const sb2Len = 4
+
+	header := make([]byte, 12)
+	header[6] = 1 // N1: one tracked satellite
+	header[7] = sb1Len
+	header[8] = sb2Len
+
+	sb1 := make([]byte, sb1Len)
+	sb1[1] = 0  // SignalType (low 5 bits) = L1CA
+	sb1[2] = 12 // SVID (GPS range)
+	sb1[3] = 1  // N2 = one Type-2 sub-block
+	sb1[12] = 160
+	binary.LittleEndian.PutUint16(sb1[13:15], 3600)
+
+	sb2 := make([]byte, sb2Len)
+	sb2[0] = 4 // SignalType (low 5 bits) = L5
+	sb2[2] = 120
+
+	payload := append(header, sb1...)
+	payload = append(payload, sb2...)
+	return payload
+}
+
+func TestDecodeMeasEpochTracksSatelliteAndSignals(t *testing.T) {
+	station := "measepochstation"
+	payload := buildMeasEpochPayload()
+
+	decodeMeasEpoch(station, 0, payload, testLogger())
+
+	st := stationState(station)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.satellitesTracked.value != 1 {
+		t.Errorf("satellitesTracked = %v, want 1", st.satellitesTracked.value)
+	}
+	gpsTracked, ok := st.satelliteTrackedByConstellation["gps"]
+	if !ok || gpsTracked.value != 1 {
+		t.Errorf("satelliteTrackedByConstellation[gps] = %+v, want value 1", gpsTracked)
+	}
+
+	l1ca, ok := st.satelliteSeries["12|l1ca"]
+	if !ok {
+		t.Fatal("missing satellite series for 12|l1ca")
+	}
+	if l1ca.cn0.value != 40.0 {
+		t.Errorf("l1ca cn0 = %v, want 40.0", l1ca.cn0.value)
+	}
+	if l1ca.lockTime.value != 3600 {
+		t.Errorf("l1ca lockTime = %v, want 3600", l1ca.lockTime.value)
+	}
+	if l1ca.constellation != "gps" {
+		t.Errorf("l1ca constellation = %q, want gps", l1ca.constellation)
+	}
+
+	l5, ok := st.satelliteSeries["12|l5"]
+	if !ok {
+		t.Fatal("missing satellite series for 12|l5")
+	}
+	if l5.cn0.value != 30.0 {
+		t.Errorf("l5 cn0 = %v, want 30.0", l5.cn0.value)
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NtripConfig holds the per-station settings used when StationConfig.Protocol
+// is "ntrip". The exporter acts as an NTRIP client, fetching the mountpoint's
+// correction stream and feeding the raw bytes into the existing SBF/RTCM3
+// parsers.
+type NtripConfig struct {
+	Mountpoint  string `yaml:"mountpoint"`
+	Username    string `yaml:"username"`
+	Password    string `yaml:"password"`
+	SourceTable bool   `yaml:"source_table"` // fetch the caster's source table once at startup instead of streaming; see fetchNTRIPSourceTableOnce
+}
+
+// dialNTRIP opens a TCP connection to an NTRIP caster and performs the
+// NTRIP/2.0 request handshake (HTTP/1.1 GET of the mountpoint, with Basic
+// Auth when credentials are configured). On success it returns a ReadCloser
+// that yields the raw correction byte stream, ready to be handed to
+// handleStream unchanged.
+func dialNTRIP(address string, cfg NtripConfig, logger *log.Logger) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	req := fmt.Sprintf("GET /%s HTTP/1.1\r\n", cfg.Mountpoint)
+	req += fmt.Sprintf("Host: %s\r\n", address)
+	req += "Ntrip-Version: Ntrip/2.0\r\n"
+	req += "User-Agent: NTRIP septentrino-exporter/1.0\r\n"
+	if cfg.Username != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(cfg.Username + ":" + cfg.Password))
+		req += fmt.Sprintf("Authorization: Basic %s\r\n", auth)
+	}
+	req += "\r\n"
+
+	if _, err := conn.Write([]byte(req)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	status, err := br.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.Contains(status, " 200 ") && !strings.HasPrefix(status, "ICY 200") {
+		conn.Close()
+		return nil, fmt.Errorf("ntrip: unexpected response: %s", strings.TrimSpace(status))
+	}
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if line == "\r\n" || line == "\n" {
+			break
+		}
+	}
+
+	return &ntripStream{Conn: conn, r: br}, nil
+}
+
+// NtripMount describes one mountpoint entry (an "STR;" record) parsed from
+// an NTRIP caster's source table.
+type NtripMount struct {
+	Mountpoint string
+	Format     string
+	Carrier    string
+	NavSystem  string
+	Country    string
+}
+
+// fetchNTRIPSourceTable dials an NTRIP caster and requests its source table
+// -- a GET of the root path rather than a mountpoint -- and parses the STR
+// records from the response body. Unlike dialNTRIP this is a one-shot
+// request: the connection is closed before returning.
+func fetchNTRIPSourceTable(address string) ([]NtripMount, error) {
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	req := "GET / HTTP/1.1\r\n"
+	req += fmt.Sprintf("Host: %s\r\n", address)
+	req += "Ntrip-Version: Ntrip/2.0\r\n"
+	req += "User-Agent: NTRIP septentrino-exporter/1.0\r\n"
+	req += "\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	status, err := br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(status, " 200 ") && !strings.HasPrefix(status, "SOURCETABLE 200") {
+		return nil, fmt.Errorf("ntrip: unexpected response: %s", strings.TrimSpace(status))
+	}
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if line == "\r\n" || line == "\n" {
+			break
+		}
+	}
+
+	body, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	return parseNTRIPSourceTable(body), nil
+}
+
+// parseNTRIPSourceTable extracts STR records from a raw NTRIP source table
+// response body. Other record types (CAS;, NET;) are not exported.
+func parseNTRIPSourceTable(body []byte) []NtripMount {
+	var mounts []NtripMount
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if !strings.HasPrefix(line, "STR;") {
+			continue
+		}
+		fields := strings.Split(line, ";")
+		// STR;mountpoint;identifier;format;format-details;carrier;nav-system;network;country;...
+		if len(fields) < 9 {
+			continue
+		}
+		mounts = append(mounts, NtripMount{
+			Mountpoint: fields[1],
+			Format:     fields[3],
+			Carrier:    fields[5],
+			NavSystem:  fields[6],
+			Country:    fields[8],
+		})
+	}
+	return mounts
+}
+
+// fetchNTRIPSourceTableOnce runs the source-table lookup for a station
+// configured with Ntrip.SourceTable and logs/exports the result, then
+// returns. It's called directly from main instead of monitorStation so a
+// lookup-only station doesn't loop through the stream reconnect path.
+func fetchNTRIPSourceTableOnce(s StationConfig) {
+	address := fmt.Sprintf("%s:%d", s.Host, s.Port)
+	logger := log.New(log.Writer(), fmt.Sprintf("[%s] ", s.Name), log.LstdFlags)
+
+	mounts, err := fetchNTRIPSourceTable(address)
+	if err != nil {
+		logger.Printf("NTRIP source table request failed: %v", err)
+		return
+	}
+
+	logger.Printf("NTRIP source table: %d mountpoints", len(mounts))
+	for _, m := range mounts {
+		ntripSourceTableMountInfo.WithLabelValues(s.Name, m.Mountpoint, m.Format, m.Carrier, m.NavSystem, m.Country).Set(1)
+	}
+}
+
+// ntripStream wraps a net.Conn whose initial bytes have already been
+// buffered while parsing the HTTP handshake, so no data is lost once
+// streaming begins.
+type ntripStream struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (s *ntripStream) Read(p []byte) (int, error) {
+	return s.r.Read(p)
+}
+
+// meteredReader counts bytes pulled from an NTRIP stream and timestamps the
+// last byte seen, so ntripAgeUpdater can report gnss_ntrip_last_message_age_seconds.
+type meteredReader struct {
+	r       io.Reader
+	station string
+}
+
+func (m *meteredReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	if n > 0 {
+		ntripBytesReceivedTotal.WithLabelValues(m.station).Add(float64(n))
+		markNtripActivity(m.station)
+	}
+	return n, err
+}
+
+var (
+	ntripLastSeenMu sync.Mutex
+	ntripLastSeen   = map[string]time.Time{}
+)
+
+func markNtripActivity(station string) {
+	ntripLastSeenMu.Lock()
+	ntripLastSeen[station] = time.Now()
+	ntripLastSeenMu.Unlock()
+}
+
+// ntripAgeUpdater periodically refreshes gnss_ntrip_last_message_age_seconds
+// for station until done is closed.
+func ntripAgeUpdater(station string, done <-chan struct{}) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ntripLastSeenMu.Lock()
+			last, ok := ntripLastSeen[station]
+			ntripLastSeenMu.Unlock()
+			if ok {
+				ntripLastMessageAge.WithLabelValues(station).Set(time.Since(last).Seconds())
+			}
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestParseNTRIPSourceTable(t *testing.T) {
+	body := "" +
+		"CAS;caster.example.com;2101;Example;Example Networks;0;USA;0.0;0.0;0;0\r\n" +
+		"STR;MOUNT1;Site One;RTCM 3.2;1004(1),1005(5),1012(1);2;GPS+GLO;EXAMPLE;USA;0.00;0.00;0;0;sNTRIP;none;N;N;0;\r\n" +
+		"STR;MOUNT2;Site Two;RTCM 3.2;1074(1),1084(1);2;GPS+GLO+GAL;EXAMPLE;DEU;0.00;0.00;0;0;sNTRIP;none;N;N;0;\r\n" +
+		"ENDSOURCETABLE\r\n"
+
+	mounts := parseNTRIPSourceTable([]byte(body))
+	if len(mounts) != 2 {
+		t.Fatalf("got %d mounts, want 2", len(mounts))
+	}
+
+	want := []NtripMount{
+		{Mountpoint: "MOUNT1", Format: "RTCM 3.2", Carrier: "2", NavSystem: "GPS+GLO", Country: "USA"},
+		{Mountpoint: "MOUNT2", Format: "RTCM 3.2", Carrier: "2", NavSystem: "GPS+GLO+GAL", Country: "DEU"},
+	}
+	for i, w := range want {
+		if mounts[i] != w {
+			t.Errorf("mount %d = %+v, want %+v", i, mounts[i], w)
+		}
+	}
+}
+
+func TestParseNTRIPSourceTableIgnoresNonSTRRecords(t *testing.T) {
+	body := "NET;EXAMPLE;Example;N;N;none;none;none;none\r\nENDSOURCETABLE\r\n"
+	if mounts := parseNTRIPSourceTable([]byte(body)); len(mounts) != 0 {
+		t.Errorf("got %d mounts, want 0", len(mounts))
+	}
+}
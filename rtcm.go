@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+)
+
+// RTCMPreamble marks the start of an RTCM3 frame, as used by NTRIP casters
+// relaying corrections (as opposed to raw SBF, which uses SyncChar1/SyncChar2).
+const RTCMPreamble = 0xD3
+
+const crc24qPoly = 0x1864CFB
+
+// crc24q computes the CRC-24Q checksum (poly 0x1864CFB, init 0) used by
+// RTCM3 to validate frames.
+func crc24q(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= crc24qPoly
+			}
+		}
+	}
+	return crc & 0xFFFFFF
+}
+
+// handleRTCM3Frame reads and validates a single RTCM3 frame following a
+// preamble byte already consumed from reader, and increments
+// gnss_rtcm_messages_total for the frame's message type (DF002). It reports
+// false if the stream ended unexpectedly and the caller should stop reading.
+func handleRTCM3Frame(reader *bufio.Reader, stationName string, log *log.Logger) bool {
+	lenBuf := make([]byte, 2)
+	if _, err := io.ReadFull(reader, lenBuf); err != nil {
+		return false
+	}
+	length := binary.BigEndian.Uint16(lenBuf) & 0x3FF
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return false
+	}
+
+	crcBuf := make([]byte, 3)
+	if _, err := io.ReadFull(reader, crcBuf); err != nil {
+		return false
+	}
+
+	frame := make([]byte, 0, 3+len(payload))
+	frame = append(frame, RTCMPreamble)
+	frame = append(frame, lenBuf...)
+	frame = append(frame, payload...)
+
+	want := uint32(crcBuf[0])<<16 | uint32(crcBuf[1])<<8 | uint32(crcBuf[2])
+	if crc24q(frame) != want {
+		return true // corrupt frame, drop it but keep reading
+	}
+
+	if length < 2 {
+		return true
+	}
+	msgType := uint16(payload[0])<<4 | uint16(payload[1])>>4
+	rtcmMessagesTotal.WithLabelValues(stationName, fmt.Sprintf("%d", msgType)).Inc()
+	return true
+}
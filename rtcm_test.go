@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestCRC24Q(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want uint32
+	}{
+		{"empty", []byte{}, 0x000000},
+		{"ascii digits", []byte("123456789"), 0xCDE703},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := crc24q(c.data); got != c.want {
+				t.Errorf("crc24q(%q) = %06X, want %06X", c.data, got, c.want)
+			}
+		})
+	}
+}
+
+func TestHandleRTCM3FrameGoodCRC(t *testing.T) {
+	payload := []byte{0x3E, 0xD0, 0x00, 0x01, 0x02, 0x03}
+	length := []byte{byte(len(payload) >> 8), byte(len(payload))}
+	frame := append([]byte{RTCMPreamble}, length...)
+	frame = append(frame, payload...)
+	crc := crc24q(frame)
+	frame = append(frame, byte(crc>>16), byte(crc>>8), byte(crc))
+
+	reader := newTestReader(frame[1:]) // preamble already consumed by caller
+	logger := testLogger()
+	before := testCounterValue(t, rtcmMessagesTotal, "teststation", "1005")
+
+	if !handleRTCM3Frame(reader, "teststation", logger) {
+		t.Fatal("handleRTCM3Frame returned false for a well-formed frame")
+	}
+
+	after := testCounterValue(t, rtcmMessagesTotal, "teststation", "1005")
+	if after != before+1 {
+		t.Errorf("gnss_rtcm_messages_total{type=1005} = %v, want %v", after, before+1)
+	}
+}
+
+func TestHandleRTCM3FrameBadCRCIsDroppedNotFatal(t *testing.T) {
+	payload := []byte{0x3E, 0xD0, 0x00, 0x01, 0x02, 0x03}
+	length := []byte{byte(len(payload) >> 8), byte(len(payload))}
+	frame := append([]byte{RTCMPreamble}, length...)
+	frame = append(frame, payload...)
+	frame = append(frame, 0x00, 0x00, 0x00) // wrong CRC
+
+	reader := newTestReader(frame[1:])
+	if !handleRTCM3Frame(reader, "teststation", testLogger()) {
+		t.Fatal("handleRTCM3Frame should keep reading after a CRC mismatch, not report stream end")
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Block IDs. The SBF ID field packs a revision number into its upper 3 bits;
+// these are the base (revision-masked) IDs used to key blockDecoders.
+const (
+	BlockID_DOP            = 4001
+	BlockID_PosCovGeodetic = 4006
+	BlockID_PVTGeodetic    = 4007
+	BlockID_VelCovGeodetic = 4008
+	BlockID_ChannelStatus  = 4013
+	BlockID_ReceiverStatus = 4014 // CPU, Uptime, Temp
+	BlockID_MeasEpoch      = 4027
+	BlockID_BaseVectorGeod = 4028
+	BlockID_DiskStatus     = 4059 // Disk Space
+	BlockID_QualityInd     = 4082 // Quality Indicators (0-10)
+	BlockID_RFStatus       = 4092
+	BlockID_ReceiverTime   = 5914
+	BlockID_EndOfPVT       = 5922
+)
+
+// BlockDecoder turns the payload of one SBF block (after the common
+// TOW/WNc header) into metric updates for a station. revision is the block
+// revision carried in the upper 3 bits of the SBF ID field.
+type BlockDecoder func(stationName string, revision uint8, payload []byte, log *log.Logger)
+
+var blockDecoders = map[uint16]BlockDecoder{}
+
+func registerBlockDecoder(id uint16, d BlockDecoder) {
+	blockDecoders[id] = d
+}
+
+// dispatchBlock is called by handleStream once a frame's CRC has been
+// validated. It always counts the block, then hands it to a registered
+// decoder if one exists -- unknown or not-yet-decoded block IDs are simply
+// counted, which keeps firmware upgrades that introduce new blocks from
+// breaking anything.
+func dispatchBlock(stationName string, id uint16, revision uint8, payload []byte, log *log.Logger) {
+	sbfBlocksReceivedTotal.WithLabelValues(stationName, fmt.Sprintf("%d", id), fmt.Sprintf("%d", revision)).Inc()
+
+	if dec, ok := blockDecoders[id]; ok {
+		dec(stationName, revision, payload, log)
+	}
+}
+
+const sbfCRC16Poly = 0x1021
+
+// sbfCRC16 computes the CRC-16-CCITT (poly 0x1021, init 0) Septentrio uses
+// to protect SBF frames, over the ID+Length header fields followed by the
+// block payload.
+func sbfCRC16(header, payload []byte) uint16 {
+	var crc uint16
+	for _, b := range header {
+		crc = sbfCRC16Step(crc, b)
+	}
+	for _, b := range payload {
+		crc = sbfCRC16Step(crc, b)
+	}
+	return crc
+}
+
+func sbfCRC16Step(crc uint16, b byte) uint16 {
+	crc ^= uint16(b) << 8
+	for i := 0; i < 8; i++ {
+		if crc&0x8000 != 0 {
+			crc = (crc << 1) ^ sbfCRC16Poly
+		} else {
+			crc <<= 1
+		}
+	}
+	return crc
+}
+
+// gauge-style values (satellite counts, DOP, covariances, ...) are buffered
+// per-station in StationState and exposed via StationCollector instead of
+// live GaugeVecs; only ever-increasing counters stay as package-level
+// CounterVecs here.
+var (
+	sbfCRCErrorsTotal      = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "gnss_sbf_crc_errors_total", Help: "SBF frames dropped for failing CRC-16 validation"}, []string{"station"})
+	sbfBlocksReceivedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "gnss_sbf_blocks_received_total", Help: "SBF blocks received, by block ID and revision"}, []string{"station", "block_id", "revision"})
+	pvtEpochsTotal         = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "gnss_pvt_epochs_total", Help: "EndOfPVT markers seen, one per PVT epoch"}, []string{"station"})
+)
+
+func init() {
+	prometheus.MustRegister(sbfCRCErrorsTotal, sbfBlocksReceivedTotal, pvtEpochsTotal)
+
+	registerBlockDecoder(BlockID_PVTGeodetic, decodePVTGeodetic)
+	registerBlockDecoder(BlockID_MeasEpoch, decodeMeasEpoch)
+	registerBlockDecoder(BlockID_RFStatus, decodeRFStatus)
+	registerBlockDecoder(BlockID_ReceiverStatus, decodeReceiverStatus)
+	registerBlockDecoder(BlockID_QualityInd, decodeQualityInd)
+	registerBlockDecoder(BlockID_DiskStatus, decodeDiskStatus)
+	registerBlockDecoder(BlockID_DOP, decodeDOP)
+	registerBlockDecoder(BlockID_PosCovGeodetic, decodePosCovGeodetic)
+	registerBlockDecoder(BlockID_VelCovGeodetic, decodeVelCovGeodetic)
+	registerBlockDecoder(BlockID_ReceiverTime, decodeReceiverTime)
+	registerBlockDecoder(BlockID_EndOfPVT, decodeEndOfPVT)
+	registerBlockDecoder(BlockID_ChannelStatus, decodeChannelStatus)
+	registerBlockDecoder(BlockID_BaseVectorGeod, decodeBaseVectorGeod)
+}
+
+func decodePVTGeodetic(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	if len(payload) > 66 {
+		nrSv := payload[66]
+		stationState(stationName).setSatellitesUsed(float64(nrSv))
+	}
+}
+
+func decodeRFStatus(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	if len(payload) > 8 {
+		flags := payload[8]
+		state := 0.0
+		if (flags & 0x01) != 0 {
+			state = 1.0
+		}
+		if (flags & 0x02) != 0 {
+			state = 2.0
+		}
+		stationState(stationName).setJammingStatus(state)
+	}
+}
+
+// receiverStatusLayout gives the byte offsets of ReceiverStatus's scalar
+// fields for one SBF revision. CPULoad and Uptime have stayed put across
+// revisions; Temperature has moved as later revisions inserted fields ahead
+// of it.
+type receiverStatusLayout struct {
+	cpuLoadOffset int
+	uptimeOffset  int
+	tempOffset    int
+}
+
+// receiverStatusLayouts maps revision (the upper 3 bits of the SBF ID field)
+// to its field offsets. Revision 1 inserts a one-byte ExtError field after
+// Uptime, shifting Temperature by one byte relative to revision 0.
+var receiverStatusLayouts = map[uint8]receiverStatusLayout{
+	0: {cpuLoadOffset: 6, uptimeOffset: 7, tempOffset: 15},
+	1: {cpuLoadOffset: 6, uptimeOffset: 7, tempOffset: 16},
+}
+
+// receiverStatusLayoutFor returns the layout for revision, falling back to
+// revision 0's offsets for revisions this exporter doesn't know about yet --
+// the same best-effort stance dispatchBlock takes for unknown block IDs.
+func receiverStatusLayoutFor(revision uint8) receiverStatusLayout {
+	if l, ok := receiverStatusLayouts[revision]; ok {
+		return l
+	}
+	return receiverStatusLayouts[0]
+}
+
+func decodeReceiverStatus(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	layout := receiverStatusLayoutFor(revision)
+	need := layout.tempOffset + 1
+	if layout.uptimeOffset+4 > need {
+		need = layout.uptimeOffset + 4
+	}
+	if len(payload) < need {
+		return
+	}
+
+	st := stationState(stationName)
+	st.setCPULoad(float64(payload[layout.cpuLoadOffset]))
+	st.setUptime(float64(binary.LittleEndian.Uint32(payload[layout.uptimeOffset : layout.uptimeOffset+4])))
+	st.setTemperature(float64(int8(payload[layout.tempOffset])))
+}
+
+func decodeQualityInd(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	// Layout: Overall(u8), GNSS(u8), RF(u8) ... (offsets vary, usually start at 6 after headers)
+	// Payload: TOW(4) + WNc(2) + Qualities...
+	if len(payload) >= 9 {
+		st := stationState(stationName)
+
+		// Offset 6: Overall Quality (0-10)
+		st.setQualityOverall(float64(payload[6]))
+
+		// Offset 7: GNSS Signals (0-10)
+		st.setQualitySignal(float64(payload[7]))
+
+		// Offset 8: RF Power (0-10)
+		st.setQualityRF(float64(payload[8]))
+	}
+}
+
+func decodeDiskStatus(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	// Layout: N(u8), SB(u8), [DiskID, Capacity(u32), Used(u32)]
+	// Payload: TOW(4) + WNc(2) + N(1) + SB(1) + [Disk Data...]
+	if len(payload) >= 20 {
+		// Offset 8: DiskID
+		// Offset 9-12: Capacity (MB) - u32
+		// Offset 13-16: Used (MB) - u32
+
+		capacityMB := binary.LittleEndian.Uint32(payload[9:13])
+		usedMB := binary.LittleEndian.Uint32(payload[13:17])
+
+		freeBytes := float64(capacityMB-usedMB) * 1024 * 1024
+		stationState(stationName).setDiskFree(freeBytes)
+	}
+}
+
+func decodeDOP(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	// Layout: TOW(4) WNc(2) NrSV(1) Reserved(1) PDOP(u16*0.01) TDOP(u16*0.01) HDOP(u16*0.01) VDOP(u16*0.01) HPL(f32) VPL(f32)
+	if len(payload) >= 16 {
+		st := stationState(stationName)
+		st.setDOPPDOP(float64(binary.LittleEndian.Uint16(payload[8:10])) * 0.01)
+		st.setDOPTDOP(float64(binary.LittleEndian.Uint16(payload[10:12])) * 0.01)
+		st.setDOPHDOP(float64(binary.LittleEndian.Uint16(payload[12:14])) * 0.01)
+		st.setDOPVDOP(float64(binary.LittleEndian.Uint16(payload[14:16])) * 0.01)
+	}
+}
+
+func decodePosCovGeodetic(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	// Layout: TOW(4) WNc(2) Mode(1) Error(1) Cov_LatLat(f32) Cov_LonLon(f32) Cov_HgtHgt(f32) ...
+	if len(payload) >= 20 {
+		st := stationState(stationName)
+		st.setPosCovLatLat(float64(math.Float32frombits(binary.LittleEndian.Uint32(payload[8:12]))))
+		st.setPosCovLonLon(float64(math.Float32frombits(binary.LittleEndian.Uint32(payload[12:16]))))
+		st.setPosCovHgtHgt(float64(math.Float32frombits(binary.LittleEndian.Uint32(payload[16:20]))))
+	}
+}
+
+func decodeVelCovGeodetic(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	// Layout: TOW(4) WNc(2) Mode(1) Error(1) Cov_VnVn(f32) Cov_VeVe(f32) Cov_VuVu(f32) ...
+	if len(payload) >= 20 {
+		st := stationState(stationName)
+		st.setVelCovVnVn(float64(math.Float32frombits(binary.LittleEndian.Uint32(payload[8:12]))))
+		st.setVelCovVeVe(float64(math.Float32frombits(binary.LittleEndian.Uint32(payload[12:16]))))
+		st.setVelCovVuVu(float64(math.Float32frombits(binary.LittleEndian.Uint32(payload[16:20]))))
+	}
+}
+
+func decodeReceiverTime(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	// Layout: TOW(4) WNc(2) UTCYear(1) UTCMonth(1) UTCDay(1) UTCHour(1) UTCMin(1) UTCSec(1) DeltaLS(1) SyncLevel(1)
+	if len(payload) >= 14 {
+		stationState(stationName).setReceiverTimeSyncLevel(float64(payload[13]))
+	}
+}
+
+func decodeEndOfPVT(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	pvtEpochsTotal.WithLabelValues(stationName).Inc()
+}
+
+func decodeChannelStatus(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	// Layout: TOW(4) WNc(2) N(1) SBLength(1) [N x SBLength sub-blocks]
+	// Sub-block byte 3 carries per-channel tracking state flags.
+	if len(payload) < 8 {
+		return
+	}
+	n := int(payload[6])
+	sbLen := int(payload[7])
+	if sbLen <= 0 {
+		return
+	}
+
+	tracking := 0
+	offset := 8
+	for i := 0; i < n && offset+sbLen <= len(payload); i++ {
+		sub := payload[offset : offset+sbLen]
+		if len(sub) > 3 && sub[3]&0x01 != 0 {
+			tracking++
+		}
+		offset += sbLen
+	}
+	stationState(stationName).setChannelsTracking(float64(tracking))
+}
+
+func decodeBaseVectorGeod(stationName string, revision uint8, payload []byte, log *log.Logger) {
+	// Layout: TOW(4) WNc(2) N(1) SBLength(1) [N x SBLength sub-blocks]
+	// Sub-block: NrSV(1) Error(1) Mode(1) MisscCorrAge(1) DeltaEast(f32) DeltaNorth(f32) DeltaUp(f32) ...
+	// Only the first sub-block (the baseline to the primary base station) is exported.
+	if len(payload) < 8 {
+		return
+	}
+	n := int(payload[6])
+	sbLen := int(payload[7])
+	if n < 1 || sbLen < 16 || 8+sbLen > len(payload) {
+		return
+	}
+
+	sub := payload[8 : 8+sbLen]
+	st := stationState(stationName)
+	st.setBaseVectorNrSV(float64(sub[0]))
+	st.setBaseVectorError(float64(sub[1]))
+
+	dEast := float64(math.Float32frombits(binary.LittleEndian.Uint32(sub[4:8])))
+	dNorth := float64(math.Float32frombits(binary.LittleEndian.Uint32(sub[8:12])))
+	dUp := float64(math.Float32frombits(binary.LittleEndian.Uint32(sub[12:16])))
+	st.setBaseVectorLength(math.Sqrt(dEast*dEast + dNorth*dNorth + dUp*dUp))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestSBFCRC16(t *testing.T) {
+	cases := []struct {
+		name    string
+		header  []byte
+		payload []byte
+		want    uint16
+	}{
+		{"empty", []byte{}, nil, 0x0000},
+		{"ascii digits", []byte("123456789"), nil, 0x31C3}, // CRC-16/XMODEM check value
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := sbfCRC16(c.header, c.payload); got != c.want {
+				t.Errorf("sbfCRC16(%q) = %04X, want %04X", c.header, got, c.want)
+			}
+		})
+	}
+}
+
+func TestDecodeReceiverStatusRevision0(t *testing.T) {
+	payload := make([]byte, 16)
+	payload[6] = 42                                      // CPULoad
+	binary.LittleEndian.PutUint32(payload[7:11], 123456) // Uptime
+	var wantTemp int8 = -5
+	payload[15] = uint8(wantTemp) // Temperature
+
+	decodeReceiverStatus("rev0station", 0, payload, testLogger())
+
+	st := stationState("rev0station")
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.cpuLoad.value != 42 {
+		t.Errorf("cpuLoad = %v, want 42", st.cpuLoad.value)
+	}
+	if st.uptime.value != 123456 {
+		t.Errorf("uptime = %v, want 123456", st.uptime.value)
+	}
+	if st.temperature.value != -5 {
+		t.Errorf("temperature = %v, want -5", st.temperature.value)
+	}
+}
+
+func TestDecodeReceiverStatusRevision1ShiftedTemperature(t *testing.T) {
+	payload := make([]byte, 17)
+	payload[6] = 7
+	binary.LittleEndian.PutUint32(payload[7:11], 999)
+	var wantTemp int8 = 30
+	payload[16] = uint8(wantTemp) // Temperature, one byte further out than revision 0
+
+	decodeReceiverStatus("rev1station", 1, payload, testLogger())
+
+	st := stationState("rev1station")
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.temperature.value != 30 {
+		t.Errorf("temperature = %v, want 30", st.temperature.value)
+	}
+}
+
+func TestDecodeChannelStatusCountsTrackingChannels(t *testing.T) {
+	// Header: TOW(4) WNc(2) N(1)=3 SBLength(1)=4
+	payload := []byte{0, 0, 0, 0, 0, 0, 3, 4}
+	// Three 4-byte sub-blocks; byte 3's low bit marks "tracking".
+	payload = append(payload, 0, 0, 0, 0x01) // tracking
+	payload = append(payload, 0, 0, 0, 0x00) // not tracking
+	payload = append(payload, 0, 0, 0, 0x01) // tracking
+
+	decodeChannelStatus("chanstation", 0, payload, testLogger())
+
+	st := stationState("chanstation")
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.channelsTracking.value != 2 {
+		t.Errorf("channelsTracking = %v, want 2", st.channelsTracking.value)
+	}
+}
+
+func TestDecodeBaseVectorGeodComputesLength(t *testing.T) {
+	// Header: TOW(4) WNc(2) N(1)=1 SBLength(1)=16
+	payload := []byte{0, 0, 0, 0, 0, 0, 1, 16}
+	sub := make([]byte, 16)
+	sub[0] = 5                                                     // NrSV
+	sub[1] = 0                                                     // Error
+	binary.LittleEndian.PutUint32(sub[4:8], math.Float32bits(3))   // DeltaEast
+	binary.LittleEndian.PutUint32(sub[8:12], math.Float32bits(4))  // DeltaNorth
+	binary.LittleEndian.PutUint32(sub[12:16], math.Float32bits(0)) // DeltaUp
+	payload = append(payload, sub...)
+
+	decodeBaseVectorGeod("basevecstation", 0, payload, testLogger())
+
+	st := stationState("basevecstation")
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.baseVectorNrSV.value != 5 {
+		t.Errorf("baseVectorNrSV = %v, want 5", st.baseVectorNrSV.value)
+	}
+	if st.baseVectorLength.value != 5 {
+		t.Errorf("baseVectorLength = %v, want 5 (3-4-5 triangle)", st.baseVectorLength.value)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// newTestReader wraps raw bytes in the *bufio.Reader shape the block/frame
+// decoders expect to read from a live connection.
+func newTestReader(b []byte) *bufio.Reader {
+	return bufio.NewReader(bytes.NewReader(b))
+}
+
+// testLogger discards its output; decoders take a *log.Logger for
+// diagnostics that these tests don't assert on.
+func testLogger() *log.Logger {
+	return log.New(io.Discard, "", 0)
+}
+
+// testCounterValue reads the current value of one series of a CounterVec,
+// returning 0 if the series hasn't been observed yet.
+func testCounterValue(t *testing.T, vec *prometheus.CounterVec, labelValues ...string) float64 {
+	t.Helper()
+	m := &dto.Metric{}
+	if err := vec.WithLabelValues(labelValues...).Write(m); err != nil {
+		t.Fatalf("reading counter value: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+// testGaugeValue reads the current value of one series of a GaugeVec,
+// returning 0 if the series hasn't been observed yet.
+func testGaugeValue(t *testing.T, vec *prometheus.GaugeVec, labelValues ...string) float64 {
+	t.Helper()
+	m := &dto.Metric{}
+	if err := vec.WithLabelValues(labelValues...).Write(m); err != nil {
+		t.Fatalf("reading gauge value: %v", err)
+	}
+	return m.GetGauge().GetValue()
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// SerialConfig configures a station reached over a local serial/USB port
+// (transport: serial) instead of TCP.
+type SerialConfig struct {
+	Device   string `yaml:"device"`
+	Baud     int    `yaml:"baud"`     // defaults to 115200 if 0
+	Parity   string `yaml:"parity"`   // "none" (default), "odd", or "even"
+	StopBits int    `yaml:"stopbits"` // 1 (default) or 2
+}
+
+// FileConfig configures a station that replays a captured SBF log instead of
+// a live connection (transport: file), for testing/offline analysis.
+type FileConfig struct {
+	Path     string `yaml:"path"`
+	Realtime bool   `yaml:"realtime"` // pace playback using TOW deltas between blocks
+}
+
+const defaultSerialBaud = 115200
+
+func dialSerial(cfg SerialConfig) (io.ReadCloser, error) {
+	mode := &serial.Mode{
+		BaudRate: cfg.Baud,
+		Parity:   serialParity(cfg.Parity),
+		StopBits: serialStopBits(cfg.StopBits),
+	}
+	if mode.BaudRate == 0 {
+		mode.BaudRate = defaultSerialBaud
+	}
+	return serial.Open(cfg.Device, mode)
+}
+
+func serialParity(p string) serial.Parity {
+	switch p {
+	case "odd":
+		return serial.OddParity
+	case "even":
+		return serial.EvenParity
+	default:
+		return serial.NoParity
+	}
+}
+
+func serialStopBits(n int) serial.StopBits {
+	if n == 2 {
+		return serial.TwoStopBits
+	}
+	return serial.OneStopBit
+}
+
+// transportEndpoint returns the human-readable endpoint string used for the
+// gnss_transport_info label, which varies by transport.
+func transportEndpoint(transport string, s StationConfig, tcpAddress string) string {
+	switch transport {
+	case "serial":
+		return s.Serial.Device
+	case "file":
+		return s.File.Path
+	default:
+		return tcpAddress
+	}
+}
+
+// replayMaxGap bounds how long a realtime file replay will sleep between
+// blocks, so a gap or clock rollover in a capture doesn't stall playback.
+const replayMaxGap = 2 * time.Second
+
+// openFileReplay streams a captured SBF log file. When cfg.Realtime is set,
+// blocks are paced out using the TOW deltas between consecutive blocks'
+// first 4 payload bytes (TOW is the common first field of SBF blocks),
+// mimicking the original receiver's send rate; otherwise the file is
+// replayed as fast as handleStream can consume it.
+func openFileReplay(cfg FileConfig) (io.ReadCloser, error) {
+	f, err := os.Open(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go replaySBFFile(f, pw, cfg.Realtime)
+
+	return &fileReplayStream{PipeReader: pr, file: f}, nil
+}
+
+type fileReplayStream struct {
+	*io.PipeReader
+	file *os.File
+}
+
+func (s *fileReplayStream) Close() error {
+	s.PipeReader.Close()
+	return s.file.Close()
+}
+
+// replaySBFFile re-frames the SBF log so it can be paced; the resulting
+// bytes are fed to the pipe unchanged, and handleStream parses them exactly
+// as it would a live stream.
+func replaySBFFile(f *os.File, pw *io.PipeWriter, realtime bool) {
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	headerBuf := make([]byte, 8)
+	var lastTOW uint32
+	haveLastTOW := false
+
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if b != SyncChar1 {
+			continue
+		}
+		b, err = reader.ReadByte()
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if b != SyncChar2 {
+			continue
+		}
+		if _, err := io.ReadFull(reader, headerBuf[2:]); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+
+		length := binary.LittleEndian.Uint16(headerBuf[6:8])
+		if length < 8 || length > 8192 {
+			continue
+		}
+
+		payload := make([]byte, int(length)-8)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+
+		if realtime && len(payload) >= 4 {
+			tow := binary.LittleEndian.Uint32(payload[0:4])
+			if haveLastTOW && tow > lastTOW {
+				gap := time.Duration(tow-lastTOW) * time.Millisecond
+				if gap > replayMaxGap {
+					gap = replayMaxGap
+				}
+				time.Sleep(gap)
+			}
+			lastTOW = tow
+			haveLastTOW = true
+		}
+
+		frame := make([]byte, 0, int(length))
+		frame = append(frame, SyncChar1, SyncChar2)
+		frame = append(frame, headerBuf[2:]...)
+		frame = append(frame, payload...)
+		if _, err := pw.Write(frame); err != nil {
+			return
+		}
+	}
+}